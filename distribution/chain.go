@@ -0,0 +1,99 @@
+package distribution
+
+import (
+	// Registers sha256/sha384/sha512 with crypto.RegisterHash so digest.Digest.Validate recognizes them - without
+	// these blank imports every digest fails validation, regardless of whether it's well-formed.
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+
+	"github.com/docker/distribution"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// ChainEntry is a single node recorded while resolving a manifest, capturing enough of its descriptor to be
+// projected onto the Docker-Manifest-Chain header or inspected by callers via Chain().
+type ChainEntry struct {
+	Digest    digest.Digest `json:"digest"`
+	MediaType string        `json:"mediaType,omitempty"`
+	Size      int64         `json:"size,omitempty"`
+	Platform  string        `json:"platform,omitempty"`
+	Parent    digest.Digest `json:"parent,omitempty"`
+}
+
+// ResolutionChain records the parent/child relationships discovered while resolving a manifest: the root descriptor
+// (a manifest list, for example), the platform-specific descriptor selected from it, and any further indirection
+// such as an attestation manifest. Entries are kept in the order they were added, root first.
+type ResolutionChain struct {
+	entries []ChainEntry
+}
+
+// reset replaces the chain with a single root entry built from dgst, discarding any previously recorded entries. It
+// returns an ErrInvalidDigest if dgst fails validation.
+func (c *ResolutionChain) reset(dgst digest.Digest) error {
+	c.entries = nil
+	if dgst == "" {
+		return nil
+	}
+	return c.addChild("", distribution.Descriptor{Digest: dgst})
+}
+
+// addChild records child as having been resolved from parent. If the chain is empty, parent is ignored and child
+// becomes the root. It returns an ErrInvalidDigest, leaving the chain unchanged, if child.Digest fails validation.
+func (c *ResolutionChain) addChild(parent digest.Digest, child distribution.Descriptor) error {
+	if child.Digest == "" {
+		return nil
+	}
+	if err := child.Digest.Validate(); err != nil {
+		return ErrInvalidDigest{Digest: child.Digest, Err: err}
+	}
+	entry := ChainEntry{
+		Digest:    child.Digest,
+		MediaType: child.MediaType,
+		Size:      child.Size,
+		Platform:  formatPlatform(child.Platform),
+	}
+	if len(c.entries) > 0 {
+		entry.Parent = parent
+	}
+	c.entries = append(c.entries, entry)
+	return nil
+}
+
+// Entries returns a copy of the chain's entries, root first and leaf last.
+func (c ResolutionChain) Entries() []ChainEntry {
+	entries := make([]ChainEntry, len(c.entries))
+	copy(entries, c.entries)
+	return entries
+}
+
+// Digests returns the digest of every entry in the chain, root first and leaf last - the same order previously used
+// for the flat Docker-Manifest-Digest header.
+func (c ResolutionChain) Digests() []digest.Digest {
+	digests := make([]digest.Digest, len(c.entries))
+	for i, entry := range c.entries {
+		digests[i] = entry.Digest
+	}
+	return digests
+}
+
+// Leaf returns the most recently added entry - the deepest node resolved so far - and true, or the zero ChainEntry
+// and false if the chain is empty.
+func (c ResolutionChain) Leaf() (ChainEntry, bool) {
+	if len(c.entries) == 0 {
+		return ChainEntry{}, false
+	}
+	return c.entries[len(c.entries)-1], true
+}
+
+// formatPlatform renders platform as a compact "os/arch[/variant]" string, or the empty string if platform is nil.
+func formatPlatform(platform *ocispec.Platform) string {
+	if platform == nil {
+		return ""
+	}
+	s := platform.OS + "/" + platform.Architecture
+	if platform.Variant != "" {
+		s += "/" + platform.Variant
+	}
+	return s
+}
@@ -0,0 +1,107 @@
+package distribution
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/docker/distribution"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+)
+
+func TestResolutionChain(t *testing.T) {
+	rootDigest, _ := digest.Parse("sha256:514026875f3a6d9a679277216eb23421c1a7624b185c610c66b2c29f16715034")
+	platformDigest, _ := digest.Parse("sha256:f27c60e95c2f542902749b2aaddf4bf3ab414db42ae44ea20c3a8e5d98457e91")
+	attestationDigest, _ := digest.Parse("sha256:113136111e5d53de61ad20e8c5c08948dbdd4e46de760baf2dd5871f1c75c707")
+
+	t.Run("empty chain", func(t *testing.T) {
+		var chain ResolutionChain
+		_, ok := chain.Leaf()
+		assert.Check(t, !ok)
+		assert.Check(t, len(chain.Entries()) == 0)
+		assert.Check(t, len(chain.Digests()) == 0)
+	})
+
+	t.Run("first child becomes the root regardless of the given parent", func(t *testing.T) {
+		var chain ResolutionChain
+		chain.addChild(platformDigest, distribution.Descriptor{
+			Digest:    rootDigest,
+			MediaType: "application/vnd.oci.image.index.v1+json",
+			Size:      1234,
+		})
+		root, ok := chain.Leaf()
+		assert.Check(t, ok)
+		assert.Equal(t, root.Digest, rootDigest)
+		assert.Equal(t, root.Parent, digest.Digest(""))
+	})
+
+	t.Run("tracks parent/child relationships and projects root-first ordering", func(t *testing.T) {
+		var chain ResolutionChain
+		chain.addChild("", distribution.Descriptor{Digest: rootDigest, MediaType: "application/vnd.oci.image.index.v1+json"})
+		chain.addChild(rootDigest, distribution.Descriptor{
+			Digest:    platformDigest,
+			MediaType: "application/vnd.oci.image.manifest.v1+json",
+			Platform:  &ocispec.Platform{OS: "linux", Architecture: "amd64"},
+		})
+		chain.addChild(platformDigest, distribution.Descriptor{Digest: attestationDigest, MediaType: "application/vnd.oci.image.manifest.v1+json"})
+
+		leaf, ok := chain.Leaf()
+		assert.Check(t, ok)
+		assert.Equal(t, leaf.Digest, attestationDigest)
+		assert.Equal(t, leaf.Parent, platformDigest)
+
+		assert.Check(t, cmp.DeepEqual(chain.Digests(), []digest.Digest{rootDigest, platformDigest, attestationDigest}))
+
+		entries := chain.Entries()
+		assert.Equal(t, entries[1].Platform, "linux/amd64")
+		assert.Equal(t, entries[1].Parent, rootDigest)
+	})
+
+	t.Run("reset discards previously recorded entries", func(t *testing.T) {
+		var chain ResolutionChain
+		chain.addChild("", distribution.Descriptor{Digest: rootDigest})
+		chain.addChild(rootDigest, distribution.Descriptor{Digest: platformDigest})
+		chain.reset(platformDigest)
+
+		assert.Check(t, cmp.DeepEqual(chain.Digests(), []digest.Digest{platformDigest}))
+	})
+
+	t.Run("entries encode to the documented JSON shape", func(t *testing.T) {
+		var chain ResolutionChain
+		chain.addChild("", distribution.Descriptor{Digest: rootDigest, MediaType: "application/vnd.oci.image.index.v1+json", Size: 512})
+		chain.addChild(rootDigest, distribution.Descriptor{
+			Digest:    platformDigest,
+			MediaType: "application/vnd.oci.image.manifest.v1+json",
+			Size:      256,
+			Platform:  &ocispec.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"},
+		})
+
+		encoded, err := json.Marshal(chain.Entries())
+		assert.NilError(t, err)
+
+		var decoded []ChainEntry
+		assert.NilError(t, json.Unmarshal(encoded, &decoded))
+		assert.Check(t, cmp.DeepEqual(decoded, chain.Entries()))
+		assert.Equal(t, decoded[1].Platform, "linux/arm64/v8")
+	})
+
+	t.Run("addChild rejects a digest whose algorithm is not registered", func(t *testing.T) {
+		var chain ResolutionChain
+		err := chain.addChild("", distribution.Descriptor{Digest: digest.Digest("sha1:0123456789012345678901234567890123456789")})
+		var invalid ErrInvalidDigest
+		assert.Check(t, errors.As(err, &invalid))
+		assert.Check(t, len(chain.Entries()) == 0)
+	})
+}
+
+// TestDigestValidate guards against a regression class where go-digest's hash-algorithm registration is missing:
+// Digest.Validate requires the algorithm's crypto package to be blank-imported (see the blank imports atop
+// chain.go), and a missing one makes every well-formed digest fail validation, not just malformed ones.
+func TestDigestValidate(t *testing.T) {
+	rootDigest, err := digest.Parse("sha256:514026875f3a6d9a679277216eb23421c1a7624b185c610c66b2c29f16715034")
+	assert.NilError(t, err)
+	assert.NilError(t, rootDigest.Validate())
+}
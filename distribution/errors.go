@@ -0,0 +1,33 @@
+package distribution
+
+import (
+	"fmt"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// ErrInvalidDigest is returned when a digest recorded on the resolution chain, or served by the registry, fails
+// digest.Digest.Validate().
+type ErrInvalidDigest struct {
+	Digest digest.Digest
+	Err    error
+}
+
+func (e ErrInvalidDigest) Error() string {
+	return fmt.Sprintf("distribution: invalid digest %q: %s", e.Digest, e.Err)
+}
+
+func (e ErrInvalidDigest) Unwrap() error {
+	return e.Err
+}
+
+// ErrDigestMismatch is returned when the Docker-Content-Digest header on a registry response does not match the
+// digest tracked for the request which produced it.
+type ErrDigestMismatch struct {
+	Expected digest.Digest
+	Actual   digest.Digest
+}
+
+func (e ErrDigestMismatch) Error() string {
+	return fmt.Sprintf("distribution: registry returned digest %q, expected %q", e.Actual, e.Expected)
+}
@@ -0,0 +1,115 @@
+package distribution
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// defaultBroadcasterRetries is the number of delivery attempts made to a single sink before giving up on an event.
+const defaultBroadcasterRetries = 3
+
+// defaultBroadcasterBackoff is the initial delay between retries, doubled after each failed attempt.
+const defaultBroadcasterBackoff = 100 * time.Millisecond
+
+// defaultBroadcasterQueueSize bounds how many events Write can buffer ahead of the delivery goroutine before it
+// starts dropping them.
+const defaultBroadcasterQueueSize = 256
+
+// EventBroadcaster fans an Event out to a set of EventSinks, retrying each sink independently with exponential
+// backoff so that one failing sink does not prevent delivery to the others. Sinks are written one after another, so a
+// slow or failing sink delays delivery to the sinks after it. Delivery happens on a background
+// goroutine so that Write never blocks the caller on a slow or unreachable sink - ModifyRequest and RoundTrip call
+// it on every pull/push and must not stall the registry operation waiting for a notification to land.
+type EventBroadcaster struct {
+	sinksMutex sync.RWMutex
+	sinks      []EventSink
+
+	retries int
+	backoff time.Duration
+
+	queue     chan Event
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// NewEventBroadcaster returns an EventBroadcaster which fans events out to sinks and starts its delivery goroutine.
+func NewEventBroadcaster(sinks ...EventSink) *EventBroadcaster {
+	b := &EventBroadcaster{
+		sinks:   sinks,
+		retries: defaultBroadcasterRetries,
+		backoff: defaultBroadcasterBackoff,
+		queue:   make(chan Event, defaultBroadcasterQueueSize),
+		done:    make(chan struct{}),
+	}
+	go b.deliverLoop()
+	return b
+}
+
+// AddSink registers sink so it receives every Event written from now on. Safe for concurrent use with Write and with
+// the delivery goroutine.
+func (b *EventBroadcaster) AddSink(sink EventSink) {
+	b.sinksMutex.Lock()
+	defer b.sinksMutex.Unlock()
+	b.sinks = append(b.sinks, sink)
+}
+
+// Write enqueues event for asynchronous delivery to every sink. It returns an error, without blocking, if the
+// internal queue is full - the caller should log and move on rather than wait, since a notification problem must
+// never stall the underlying registry operation.
+func (b *EventBroadcaster) Write(event Event) error {
+	select {
+	case b.queue <- event:
+		return nil
+	default:
+		return fmt.Errorf("event queue full, dropping event %s", event.ID)
+	}
+}
+
+// Close stops the delivery goroutine once every already-queued event has been delivered. Close closes the queue, so
+// Write must not be called after Close: it panics.
+func (b *EventBroadcaster) Close() {
+	b.closeOnce.Do(func() {
+		close(b.queue)
+	})
+	<-b.done
+}
+
+func (b *EventBroadcaster) deliverLoop() {
+	defer close(b.done)
+	for event := range b.queue {
+		b.deliver(event)
+	}
+}
+
+// deliver writes event to every sink, retrying failed deliveries with exponential backoff, logging every sink which
+// fails all of its attempts.
+func (b *EventBroadcaster) deliver(event Event) {
+	b.sinksMutex.RLock()
+	sinks := make([]EventSink, len(b.sinks))
+	copy(sinks, b.sinks)
+	b.sinksMutex.RUnlock()
+
+	for _, sink := range sinks {
+		if err := b.writeWithRetry(sink, event); err != nil {
+			logrus.WithError(err).Warn("distribution: failed to deliver event notification to sink")
+		}
+	}
+}
+
+func (b *EventBroadcaster) writeWithRetry(sink EventSink, event Event) error {
+	backoff := b.backoff
+	var err error
+	for attempt := 0; attempt < b.retries; attempt++ {
+		if err = sink.Write(event); err == nil {
+			return nil
+		}
+		if attempt < b.retries-1 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+	return err
+}
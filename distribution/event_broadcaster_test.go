@@ -0,0 +1,85 @@
+package distribution
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/poll"
+)
+
+type failingSink struct {
+	mutex    sync.Mutex
+	failures int
+	calls    int
+}
+
+func (s *failingSink) Write(event Event) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.calls++
+	if s.calls <= s.failures {
+		return errors.New("sink unavailable")
+	}
+	return nil
+}
+
+func (s *failingSink) callCount() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.calls
+}
+
+func TestEventBroadcaster_Write(t *testing.T) {
+	t.Run("delivers to all sinks", func(t *testing.T) {
+		sink1 := NewInMemoryEventSink()
+		sink2 := NewInMemoryEventSink()
+		broadcaster := NewEventBroadcaster(sink1, sink2)
+
+		assert.NilError(t, broadcaster.Write(Event{ID: "1", Action: ActionPull}))
+		poll.WaitOn(t, func(t poll.LogT) poll.Result {
+			if len(sink1.Events()) == 1 && len(sink2.Events()) == 1 {
+				return poll.Success()
+			}
+			return poll.Continue("waiting for delivery to both sinks")
+		})
+	})
+
+	t.Run("retries a failing sink before giving up", func(t *testing.T) {
+		sink := &failingSink{failures: 1}
+		broadcaster := NewEventBroadcaster(sink)
+		broadcaster.backoff = time.Millisecond
+
+		assert.NilError(t, broadcaster.Write(Event{ID: "2", Action: ActionPush}))
+		poll.WaitOn(t, func(t poll.LogT) poll.Result {
+			if sink.callCount() == 2 {
+				return poll.Success()
+			}
+			return poll.Continue("waiting for retry to succeed")
+		})
+	})
+
+	t.Run("gives up on a sink which fails every attempt", func(t *testing.T) {
+		sink := &failingSink{failures: 99}
+		broadcaster := NewEventBroadcaster(sink)
+		broadcaster.backoff = time.Millisecond
+
+		assert.NilError(t, broadcaster.Write(Event{ID: "3", Action: ActionDelete}))
+		poll.WaitOn(t, func(t poll.LogT) poll.Result {
+			if sink.callCount() == broadcaster.retries {
+				return poll.Success()
+			}
+			return poll.Continue("waiting for retries to exhaust")
+		})
+	})
+
+	t.Run("Write never blocks once the queue is full", func(t *testing.T) {
+		broadcaster := &EventBroadcaster{queue: make(chan Event, 1)} // never drained, by construction
+
+		assert.NilError(t, broadcaster.Write(Event{ID: "4"}))
+		err := broadcaster.Write(Event{ID: "5"})
+		assert.ErrorContains(t, err, "event queue full")
+	})
+}
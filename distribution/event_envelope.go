@@ -0,0 +1,7 @@
+package distribution
+
+// EventEnvelope is the wire format used to batch one or more Events into a single notification, matching the shape
+// expected by the registry notification receivers this subsystem is modeled after.
+type EventEnvelope struct {
+	Events []Event `json:"events"`
+}
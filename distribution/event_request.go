@@ -0,0 +1,35 @@
+package distribution
+
+import (
+	"net/http"
+	"regexp"
+)
+
+// registryURLPattern matches the distribution registry API v2 paths for manifests and blobs, capturing the
+// repository name, the resource kind ("manifests" or "blobs") and the reference (a tag or a digest).
+var registryURLPattern = regexp.MustCompile(`^/v2/(.+)/(manifests|blobs)/([^/]+)$`)
+
+// actionForRequest maps an HTTP method observed against a manifest or blob URL to the Action it represents. The
+// second return value is false for methods which aren't tracked as notification-worthy (e.g. HEAD).
+func actionForRequest(method string) (Action, bool) {
+	switch method {
+	case http.MethodGet:
+		return ActionPull, true
+	case http.MethodPut:
+		return ActionPush, true
+	case http.MethodDelete:
+		return ActionDelete, true
+	default:
+		return "", false
+	}
+}
+
+// targetForRequest inspects req for a registry API v2 manifest or blob URL and, if found, returns the repository,
+// resource kind and reference (tag or digest) it addresses.
+func targetForRequest(req *http.Request) (repository, kind, reference string, ok bool) {
+	match := registryURLPattern.FindStringSubmatch(req.URL.Path)
+	if match == nil {
+		return "", "", "", false
+	}
+	return match[1], match[2], match[3], true
+}
@@ -0,0 +1,61 @@
+package distribution
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestActionForRequest(t *testing.T) {
+	cases := []struct {
+		method string
+		action Action
+		ok     bool
+	}{
+		{http.MethodGet, ActionPull, true},
+		{http.MethodPut, ActionPush, true},
+		{http.MethodDelete, ActionDelete, true},
+		{http.MethodHead, "", false},
+		{http.MethodPost, "", false},
+	}
+	for _, c := range cases {
+		action, ok := actionForRequest(c.method)
+		assert.Equal(t, ok, c.ok, c.method)
+		assert.Equal(t, action, c.action, c.method)
+	}
+}
+
+func TestTargetForRequest(t *testing.T) {
+	newRequest := func(path string) *http.Request {
+		return httptest.NewRequest(http.MethodGet, "https://www.example.com"+path, nil)
+	}
+
+	t.Run("manifest URL by tag", func(t *testing.T) {
+		repository, kind, reference, ok := targetForRequest(newRequest("/v2/library/alpine/manifests/latest"))
+		assert.Check(t, ok)
+		assert.Equal(t, repository, "library/alpine")
+		assert.Equal(t, kind, "manifests")
+		assert.Equal(t, reference, "latest")
+	})
+
+	t.Run("blob URL by digest", func(t *testing.T) {
+		repository, kind, reference, ok := targetForRequest(newRequest("/v2/library/alpine/blobs/sha256:abc123"))
+		assert.Check(t, ok)
+		assert.Equal(t, repository, "library/alpine")
+		assert.Equal(t, kind, "blobs")
+		assert.Equal(t, reference, "sha256:abc123")
+	})
+
+	t.Run("nested repository name", func(t *testing.T) {
+		repository, _, _, ok := targetForRequest(newRequest("/v2/a/b/c/manifests/latest"))
+		assert.Check(t, ok)
+		assert.Equal(t, repository, "a/b/c")
+	})
+
+	t.Run("non-registry URL does not match", func(t *testing.T) {
+		_, _, _, ok := targetForRequest(newRequest("/healthz"))
+		assert.Check(t, !ok)
+	})
+}
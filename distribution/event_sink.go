@@ -0,0 +1,132 @@
+package distribution
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultHTTPSinkTimeout bounds how long a single event delivery attempt may take, so a slow or unreachable
+// receiver can't stall the delivery goroutine indefinitely.
+const defaultHTTPSinkTimeout = 5 * time.Second
+
+// EventSink receives Events produced by the registry notifications subsystem. Implementations must be safe for
+// concurrent use, since a single sink may be shared by multiple repository wrappers.
+type EventSink interface {
+	Write(event Event) error
+}
+
+// InMemoryEventSink is an EventSink which keeps the events it receives in memory, primarily useful for tests and for
+// operators who want to inspect recent activity without standing up an external receiver.
+type InMemoryEventSink struct {
+	mutex  sync.RWMutex
+	events []Event
+}
+
+// NewInMemoryEventSink returns a new, empty InMemoryEventSink.
+func NewInMemoryEventSink() *InMemoryEventSink {
+	return &InMemoryEventSink{}
+}
+
+// Write appends event to the sink.
+func (s *InMemoryEventSink) Write(event Event) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+// Events returns a copy of the events recorded by the sink so far, oldest first.
+func (s *InMemoryEventSink) Events() []Event {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	events := make([]Event, len(s.events))
+	copy(events, s.events)
+	return events
+}
+
+// HTTPSinkConfig configures an HTTPEventSink. It mirrors the daemon-config shape operators use to point the
+// notifications subsystem at an external receiver, but reading that daemon config and constructing an
+// HTTPSinkConfig from it is the caller's responsibility - this package only wires a sink onto a repository once one
+// exists (NewRepositoryWithManifestInfo, addEventSinkToRepoWithManifestInfo).
+type HTTPSinkConfig struct {
+	// Endpoint is the URL events are POSTed to.
+	Endpoint string
+	// Headers are additional headers sent with every request, e.g. for authentication.
+	Headers http.Header
+	// TLSClientConfig, when set, is used for the underlying HTTP client transport.
+	TLSClientConfig *tls.Config
+	// Actions restricts the sink to the given set of actions. An empty slice means no filtering - all actions are
+	// delivered.
+	Actions []Action
+	// Timeout bounds how long a single delivery attempt may take. Defaults to defaultHTTPSinkTimeout.
+	Timeout time.Duration
+}
+
+// HTTPEventSink is an EventSink which delivers events to an external receiver over HTTP, as configured by
+// HTTPSinkConfig.
+type HTTPEventSink struct {
+	endpoint string
+	headers  http.Header
+	client   *http.Client
+	actions  map[Action]bool
+}
+
+// NewHTTPEventSink returns an HTTPEventSink which POSTs events to cfg.Endpoint as JSON.
+func NewHTTPEventSink(cfg HTTPSinkConfig) *HTTPEventSink {
+	var actions map[Action]bool
+	if len(cfg.Actions) > 0 {
+		actions = make(map[Action]bool, len(cfg.Actions))
+		for _, action := range cfg.Actions {
+			actions[action] = true
+		}
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultHTTPSinkTimeout
+	}
+	return &HTTPEventSink{
+		endpoint: cfg.Endpoint,
+		headers:  cfg.Headers,
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: &http.Transport{TLSClientConfig: cfg.TLSClientConfig},
+		},
+		actions: actions,
+	}
+}
+
+// Write POSTs event to the configured endpoint. Events whose action is not in the sink's filter are silently
+// dropped.
+func (s *HTTPEventSink) Write(event Event) error {
+	if s.actions != nil && !s.actions[event.Action] {
+		return nil
+	}
+	body, err := json.Marshal(EventEnvelope{Events: []Event{event}})
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build event notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/vnd.docker.distribution.events.v1+json")
+	for key, values := range s.headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("deliver event notification: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("event notification receiver returned status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package distribution
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+)
+
+func TestHTTPEventSink_Write(t *testing.T) {
+	t.Run("posts the event envelope with the configured headers", func(t *testing.T) {
+		var gotBody EventEnvelope
+		var gotHeaders http.Header
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			gotHeaders = req.Header.Clone()
+			assert.NilError(t, json.NewDecoder(req.Body).Decode(&gotBody))
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		sink := NewHTTPEventSink(HTTPSinkConfig{
+			Endpoint: server.URL,
+			Headers:  http.Header{"Authorization": []string{"Bearer token"}},
+		})
+		event := Event{ID: "1", Action: ActionPush, Target: Target{Repository: "library/alpine"}}
+		assert.NilError(t, sink.Write(event))
+
+		assert.Check(t, cmp.DeepEqual(gotBody.Events, []Event{event}))
+		assert.Equal(t, gotHeaders.Get("Authorization"), "Bearer token")
+		assert.Equal(t, gotHeaders.Get("Content-Type"), "application/vnd.docker.distribution.events.v1+json")
+	})
+
+	t.Run("drops actions outside the configured filter", func(t *testing.T) {
+		called := false
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			called = true
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		sink := NewHTTPEventSink(HTTPSinkConfig{Endpoint: server.URL, Actions: []Action{ActionPush}})
+		assert.NilError(t, sink.Write(Event{ID: "2", Action: ActionPull}))
+		assert.Check(t, !called)
+	})
+
+	t.Run("delivers actions included in the configured filter", func(t *testing.T) {
+		called := false
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			called = true
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		sink := NewHTTPEventSink(HTTPSinkConfig{Endpoint: server.URL, Actions: []Action{ActionPush}})
+		assert.NilError(t, sink.Write(Event{ID: "3", Action: ActionPush}))
+		assert.Check(t, called)
+	})
+
+	t.Run("returns an error for a non-2xx response", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		sink := NewHTTPEventSink(HTTPSinkConfig{Endpoint: server.URL})
+		err := sink.Write(Event{ID: "4"})
+		assert.ErrorContains(t, err, "event notification receiver returned status")
+	})
+
+	t.Run("times out against an unresponsive receiver", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			time.Sleep(50 * time.Millisecond)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		sink := NewHTTPEventSink(HTTPSinkConfig{Endpoint: server.URL, Timeout: time.Millisecond})
+		err := sink.Write(Event{ID: "5"})
+		assert.Check(t, err != nil)
+	})
+}
@@ -0,0 +1,64 @@
+package distribution
+
+import (
+	"time"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// Action identifies the kind of registry operation an Event describes.
+type Action string
+
+const (
+	// ActionPull is recorded when a manifest or blob is read from the registry.
+	ActionPull Action = "pull"
+	// ActionPush is recorded when a manifest or blob is written to the registry.
+	ActionPush Action = "push"
+	// ActionDelete is recorded when a manifest or blob is deleted from the registry.
+	ActionDelete Action = "delete"
+)
+
+// Target describes the manifest or blob an Event is about.
+type Target struct {
+	// MediaType is the content type of the manifest or blob, when known.
+	MediaType string `json:"mediaType,omitempty"`
+	// Digest is the content digest of the manifest or blob.
+	Digest digest.Digest `json:"digest,omitempty"`
+	// Size is the size in bytes of the manifest or blob, when known.
+	Size int64 `json:"size,omitempty"`
+	// Repository is the name of the repository the target belongs to.
+	Repository string `json:"repository,omitempty"`
+	// URL is the request URL the target was read from or written to.
+	URL string `json:"url,omitempty"`
+	// Tag is the tag associated with the target, if any.
+	Tag string `json:"tag,omitempty"`
+}
+
+// RequestRecord captures the details of the HTTP request which triggered an Event.
+type RequestRecord struct {
+	// ID is a unique identifier correlating this record with the originating request.
+	ID string `json:"id,omitempty"`
+	// Addr is the remote address the request was made from.
+	Addr string `json:"addr,omitempty"`
+	// Host is the registry host the request was made to.
+	Host string `json:"host,omitempty"`
+	// Method is the HTTP method of the request (GET, PUT, DELETE, ...).
+	Method string `json:"method,omitempty"`
+	// UserAgent is the User-Agent header sent with the request.
+	UserAgent string `json:"useragent,omitempty"`
+}
+
+// Event describes a single push/pull/delete operation observed while resolving or transferring a manifest or blob,
+// so it can be forwarded to an EventSink.
+type Event struct {
+	// ID uniquely identifies this event.
+	ID string `json:"id"`
+	// Timestamp is when the event was recorded.
+	Timestamp time.Time `json:"timestamp"`
+	// Action is the operation the event describes.
+	Action Action `json:"action"`
+	// Target is the manifest or blob the action was performed on.
+	Target Target `json:"target"`
+	// Request carries details of the HTTP request which triggered the event.
+	Request RequestRecord `json:"request"`
+}
@@ -0,0 +1,128 @@
+package distribution
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ChainScope controls how much of a ResolutionChain a HeaderPolicy projects onto outgoing requests.
+type ChainScope int
+
+const (
+	// ChainFull projects every entry in the chain, root first.
+	ChainFull ChainScope = iota
+	// ChainLeafOnly projects only the leaf (deepest resolved) entry.
+	ChainLeafOnly
+	// ChainRootOnly projects only the root entry.
+	ChainRootOnly
+)
+
+// entriesForScope returns the subset of chain.Entries() that scope selects.
+func entriesForScope(chain ResolutionChain, scope ChainScope) []ChainEntry {
+	entries := chain.Entries()
+	switch scope {
+	case ChainLeafOnly:
+		if len(entries) == 0 {
+			return nil
+		}
+		return entries[len(entries)-1:]
+	case ChainRootOnly:
+		if len(entries) == 0 {
+			return nil
+		}
+		return entries[:1]
+	default:
+		return entries
+	}
+}
+
+// HeaderPolicy decides which headers ModifyRequest adds to outgoing requests to carry the tracked tag and
+// resolution chain, and how their values are encoded. Operators can inject a HeaderPolicy to match what their
+// registry, proxy or pull-through cache expects, without patching the daemon.
+type HeaderPolicy interface {
+	// Apply writes the headers derived from tag and chain onto req.
+	Apply(req *http.Request, tag string, chain ResolutionChain)
+}
+
+// DefaultHeaderPolicy reproduces the wrapper's original behavior: Docker-Manifest-Tag carries the tag, and
+// Docker-Manifest-Digest is repeated once per digest in the selected chain scope, alongside a Docker-Manifest-Chain
+// header carrying the selected entries as JSON.
+type DefaultHeaderPolicy struct {
+	Scope ChainScope
+}
+
+// NewDefaultHeaderPolicy returns a DefaultHeaderPolicy projecting the given scope of the chain.
+func NewDefaultHeaderPolicy(scope ChainScope) *DefaultHeaderPolicy {
+	return &DefaultHeaderPolicy{Scope: scope}
+}
+
+// Apply implements HeaderPolicy.
+func (p *DefaultHeaderPolicy) Apply(req *http.Request, tag string, chain ResolutionChain) {
+	if tag != "" {
+		req.Header.Set("Docker-Manifest-Tag", tag)
+	}
+	entries := entriesForScope(chain, p.Scope)
+	for _, entry := range entries {
+		req.Header.Add("Docker-Manifest-Digest", entry.Digest.String())
+	}
+	if len(entries) == 0 {
+		return
+	}
+	if encoded, err := json.Marshal(entries); err != nil {
+		logrus.WithError(err).Warn("DefaultHeaderPolicy: failed to encode resolution chain")
+	} else {
+		req.Header.Set("Docker-Manifest-Chain", string(encoded))
+	}
+}
+
+// OCIHeaderPolicy carries the tag and each selected chain entry's digest combined into a single OCI-Manifest-
+// Reference header value per entry, matching the reference package's combined tag+digest form ("tag@sha256:...").
+type OCIHeaderPolicy struct {
+	Scope ChainScope
+}
+
+// NewOCIHeaderPolicy returns an OCIHeaderPolicy projecting the given scope of the chain.
+func NewOCIHeaderPolicy(scope ChainScope) *OCIHeaderPolicy {
+	return &OCIHeaderPolicy{Scope: scope}
+}
+
+// Apply implements HeaderPolicy.
+func (p *OCIHeaderPolicy) Apply(req *http.Request, tag string, chain ResolutionChain) {
+	for _, entry := range entriesForScope(chain, p.Scope) {
+		value := entry.Digest.String()
+		if tag != "" {
+			value = tag + "@" + value
+		}
+		req.Header.Add("OCI-Manifest-Reference", value)
+	}
+}
+
+// CSVHeaderPolicy carries the selected chain's digests as a single comma-separated Docker-Manifest-Digest header
+// value, for proxies that drop or mangle repeated headers.
+type CSVHeaderPolicy struct {
+	Scope ChainScope
+}
+
+// NewCSVHeaderPolicy returns a CSVHeaderPolicy projecting the given scope of the chain.
+func NewCSVHeaderPolicy(scope ChainScope) *CSVHeaderPolicy {
+	return &CSVHeaderPolicy{Scope: scope}
+}
+
+// Apply implements HeaderPolicy.
+func (p *CSVHeaderPolicy) Apply(req *http.Request, tag string, chain ResolutionChain) {
+	if tag != "" {
+		req.Header.Set("Docker-Manifest-Tag", tag)
+	}
+	entries := entriesForScope(chain, p.Scope)
+	if len(entries) == 0 {
+		return
+	}
+	digests := make([]string, len(entries))
+	for i, entry := range entries {
+		digests[i] = entry.Digest.String()
+	}
+	req.Header.Set("Docker-Manifest-Digest", strings.Join(digests, ","))
+}
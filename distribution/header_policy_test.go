@@ -0,0 +1,86 @@
+package distribution
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+)
+
+func TestHeaderPolicies(t *testing.T) {
+	rootDigest, _ := digest.Parse("sha256:514026875f3a6d9a679277216eb23421c1a7624b185c610c66b2c29f16715034")
+	leafDigest, _ := digest.Parse("sha256:f27c60e95c2f542902749b2aaddf4bf3ab414db42ae44ea20c3a8e5d98457e91")
+	var chain ResolutionChain
+	assert.NilError(t, chain.addChild("", distribution.Descriptor{Digest: rootDigest}))
+	assert.NilError(t, chain.addChild(rootDigest, distribution.Descriptor{Digest: leafDigest}))
+
+	newRequest := func() *http.Request { return httptest.NewRequest(http.MethodGet, "https://www.example.com", nil) }
+
+	t.Run("DefaultHeaderPolicy reproduces the original headers", func(t *testing.T) {
+		req := newRequest()
+		NewDefaultHeaderPolicy(ChainFull).Apply(req, "latest", chain)
+		assert.Equal(t, req.Header.Get("Docker-Manifest-Tag"), "latest")
+		assert.Check(t, cmp.DeepEqual(req.Header.Values("Docker-Manifest-Digest"), []string{rootDigest.String(), leafDigest.String()}))
+		assert.Check(t, req.Header.Get("Docker-Manifest-Chain") != "")
+	})
+
+	t.Run("DefaultHeaderPolicy with ChainLeafOnly emits only the leaf", func(t *testing.T) {
+		req := newRequest()
+		NewDefaultHeaderPolicy(ChainLeafOnly).Apply(req, "latest", chain)
+		assert.Check(t, cmp.DeepEqual(req.Header.Values("Docker-Manifest-Digest"), []string{leafDigest.String()}))
+	})
+
+	t.Run("DefaultHeaderPolicy with ChainRootOnly emits only the root", func(t *testing.T) {
+		req := newRequest()
+		NewDefaultHeaderPolicy(ChainRootOnly).Apply(req, "latest", chain)
+		assert.Check(t, cmp.DeepEqual(req.Header.Values("Docker-Manifest-Digest"), []string{rootDigest.String()}))
+	})
+
+	t.Run("OCIHeaderPolicy combines tag and digest per entry", func(t *testing.T) {
+		req := newRequest()
+		NewOCIHeaderPolicy(ChainFull).Apply(req, "latest", chain)
+		assert.Check(t, cmp.DeepEqual(req.Header.Values("OCI-Manifest-Reference"), []string{
+			"latest@" + rootDigest.String(),
+			"latest@" + leafDigest.String(),
+		}))
+	})
+
+	t.Run("CSVHeaderPolicy joins digests into one header value", func(t *testing.T) {
+		req := newRequest()
+		NewCSVHeaderPolicy(ChainFull).Apply(req, "latest", chain)
+		assert.Equal(t, req.Header.Get("Docker-Manifest-Digest"), rootDigest.String()+","+leafDigest.String())
+	})
+}
+
+func TestNewRepositoryWithManifestInfo(t *testing.T) {
+	named, err := reference.WithName("library/alpine")
+	assert.NilError(t, err)
+
+	backing := &namedOnlyRepository{named: named}
+	repo := NewRepositoryWithManifestInfo(backing, NewOCIHeaderPolicy(ChainFull))
+	wrapper, ok := repo.(*distributionRepositoryWithManifestInfo)
+	assert.Check(t, ok)
+	assert.Equal(t, wrapper.repository, "library/alpine")
+
+	req := httptest.NewRequest(http.MethodGet, "https://www.example.com", nil)
+	dgst, _ := digest.Parse("sha256:514026875f3a6d9a679277216eb23421c1a7624b185c610c66b2c29f16715034")
+	assert.NilError(t, wrapper.addChild(ActionPull, "", distribution.Descriptor{Digest: dgst}))
+	assert.NilError(t, wrapper.ModifyRequest(req))
+	assert.Equal(t, req.Header.Get("OCI-Manifest-Reference"), dgst.String())
+}
+
+// namedOnlyRepository is a minimal distribution.Repository stub exercising only Named(), enough to test
+// NewRepositoryWithManifestInfo's repository-name wiring without a full registry client.
+type namedOnlyRepository struct {
+	distribution.Repository
+	named reference.Named
+}
+
+func (n *namedOnlyRepository) Named() reference.Named {
+	return n.named
+}
@@ -3,26 +3,117 @@ package distribution
 import (
 	"github.com/docker/distribution"
 	"github.com/docker/distribution/reference"
+	"github.com/google/uuid"
 	"github.com/opencontainers/go-digest"
 	"github.com/sirupsen/logrus"
 	"net/http"
 	"sync"
+	"time"
 )
 
 // distributionRepositoryWithManifestInfo is a distribution.Repository implementation wrapper which keeps track on the
-// manifest information (the tag and the digests) being pushed / pulled. It also acts as transport.RequestModifier to
-// modify requests, adding headers with the manifest tag and digests.
+// manifest information (the tag and the resolution chain) being pushed / pulled. It also acts as
+// transport.RequestModifier to modify requests, adding headers with the manifest tag and the resolution chain.
 //
-// Multiple digests are collected e.g. in the scenario of pulling a manifest list. In this case, the digests are
-// accumulated every time a specific manifest is resolved. The leftmost (i.e. index 0) digest is expected to be the
-// first requested manifest, the rightmost (i.e. last index) is the last resolved manifest in the chain.
+// The chain is built e.g. in the scenario of pulling a manifest list: the root descriptor (the manifest list) is
+// recorded first, then the selected platform descriptor is recorded as its child, and so on for any further
+// indirection such as an attestation manifest. See ResolutionChain.
+//
+// The wrapper also doubles as the pivot point of the registry notifications subsystem: every time the tracked
+// manifest info changes, or ModifyRequest observes a request against a manifest or blob URL, an Event is synthesized
+// and fanned out to the registered EventSinks.
 type distributionRepositoryWithManifestInfo struct {
 	distribution.Repository
 	manifestInfo struct {
-		tag     string
-		digests []string
+		tag   string
+		chain ResolutionChain
+	}
+	mutex       sync.RWMutex
+	repository  string
+	broadcaster *EventBroadcaster
+
+	// tagDigests holds, per tag, every digest observed for it during this instance's lifetime, oldest first. It is
+	// bounded by tagHistoryCap, evicting the least recently inserted tag once the cap is exceeded.
+	tagDigests      map[string][]digest.Digest
+	tagHistoryOrder []string
+	tagHistoryCap   int
+
+	// transport is the base http.RoundTripper RoundTrip delivers requests to. It defaults to http.DefaultTransport.
+	transport http.RoundTripper
+	// lastResolutionErr holds the outcome of the most recent Docker-Content-Digest cross-check performed by
+	// RoundTrip, nil if it matched the tracked leaf digest or nothing has been checked yet.
+	lastResolutionErr error
+
+	// headerPolicy decides which headers ModifyRequest adds and how they're encoded. It defaults to
+	// NewDefaultHeaderPolicy(ChainFull).
+	headerPolicy HeaderPolicy
+}
+
+// NewRepositoryWithManifestInfo wraps repo so manifest tag and resolution-chain information is tracked and
+// projected onto outgoing requests according to policy. A nil policy falls back to
+// NewDefaultHeaderPolicy(ChainFull), reproducing the wrapper's original behavior. Any sinks given are registered on
+// the wrapper's notifications subsystem immediately, equivalent to calling addEventSinkToRepoWithManifestInfo for
+// each of them after construction.
+func NewRepositoryWithManifestInfo(repo distribution.Repository, policy HeaderPolicy, sinks ...EventSink) distribution.Repository {
+	if policy == nil {
+		policy = NewDefaultHeaderPolicy(ChainFull)
+	}
+	wrapper := &distributionRepositoryWithManifestInfo{Repository: repo, headerPolicy: policy}
+	if named := repo.Named(); named != nil {
+		wrapper.repository = named.Name()
+	}
+	if len(sinks) > 0 {
+		wrapper.broadcaster = NewEventBroadcaster(sinks...)
+	}
+	return wrapper
+}
+
+// policy returns the configured header policy, or NewDefaultHeaderPolicy(ChainFull) if none was configured.
+func (r *distributionRepositoryWithManifestInfo) policy() HeaderPolicy {
+	if r.headerPolicy != nil {
+		return r.headerPolicy
+	}
+	return NewDefaultHeaderPolicy(ChainFull)
+}
+
+// addEventSink registers sink so it receives every Event emitted by this wrapper from now on.
+func (r *distributionRepositoryWithManifestInfo) addEventSink(sink EventSink) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.broadcaster == nil {
+		r.broadcaster = NewEventBroadcaster(sink)
+		return
+	}
+	r.broadcaster.AddSink(sink)
+}
+
+// emit synthesizes an Event and fans it out to the registered sinks, if any. Delivery failures are logged, not
+// returned, since a notification problem must never fail the underlying registry operation.
+func (r *distributionRepositoryWithManifestInfo) emit(action Action, target Target, req *http.Request) {
+	r.mutex.RLock()
+	broadcaster := r.broadcaster
+	r.mutex.RUnlock()
+	if broadcaster == nil {
+		return
+	}
+	event := Event{
+		ID:        uuid.NewString(),
+		Timestamp: time.Now(),
+		Action:    action,
+		Target:    target,
+	}
+	if req != nil {
+		event.Request = RequestRecord{
+			ID:        req.Header.Get("X-Request-Id"),
+			Addr:      req.RemoteAddr,
+			Host:      req.Host,
+			Method:    req.Method,
+			UserAgent: req.UserAgent(),
+		}
+	}
+	if err := broadcaster.Write(event); err != nil {
+		logrus.WithError(err).Warn("distributionRepositoryWithManifestInfo: failed to deliver event notification")
 	}
-	mutex sync.RWMutex
 }
 
 var _ distribution.Repository = (*distributionRepositoryWithManifestInfo)(nil)
@@ -30,61 +121,109 @@ var _ distribution.Repository = (*distributionRepositoryWithManifestInfo)(nil)
 func (r *distributionRepositoryWithManifestInfo) ModifyRequest(req *http.Request) error {
 	logrus.Tracef("distributionRepositoryWithManifestInfo.ModifyRequest: %s %s", req.Method, req.URL)
 	r.mutex.RLock()
-	defer r.mutex.RUnlock()
 	info := r.manifestInfo
-	if info.tag != "" {
-		logrus.Tracef("Adding manifest header - Docker-Manifest-Tag: %s", info.tag)
-		req.Header.Set("Docker-Manifest-Tag", info.tag)
-	}
-	if len(info.digests) > 0 {
-		logrus.Tracef("Adding manifest header - Docker-Manifest-Digest: %s", info.digests)
-		for _, value := range info.digests {
-			req.Header.Add("Docker-Manifest-Digest", value)
-		}
-	}
+	r.mutex.RUnlock()
+	r.policy().Apply(req, info.tag, info.chain)
+	r.emitForRequest(req)
 	return nil
 }
 
+// emitForRequest synthesizes and broadcasts an Event if req targets a manifest or blob URL with a method the
+// notifications subsystem tracks (GET/PUT/DELETE).
+func (r *distributionRepositoryWithManifestInfo) emitForRequest(req *http.Request) {
+	action, ok := actionForRequest(req.Method)
+	if !ok {
+		return
+	}
+	repository, kind, reference, ok := targetForRequest(req)
+	if !ok {
+		return
+	}
+	target := Target{Repository: repository, URL: req.URL.String()}
+	if dgst, err := digest.Parse(reference); err == nil {
+		target.Digest = dgst
+	} else if kind == "manifests" {
+		target.Tag = reference
+	}
+	r.emit(action, target, req)
+}
+
 // update the manifest info kept by this instance according to the given named ref and the optional list of
-// digests.
+// digests, emitting an event tagged with action (e.g. ActionPull for a pull, ActionPush for a push). It returns an
+// ErrInvalidDigest, leaving the previous state unchanged, if any digest fails validation.
 //
 // Note - if both ref is a reference.Digested and digests is not empty, then the digests have priority over the digest
-// from the ref.
-func (r *distributionRepositoryWithManifestInfo) update(ref reference.Named, digests ...digest.Digest) {
+// from the ref. A multi-digest update resets the chain to a single linear path through the given digests, root
+// first.
+func (r *distributionRepositoryWithManifestInfo) update(action Action, ref reference.Named, digests ...digest.Digest) error {
 	r.mutex.Lock()
-	defer r.mutex.Unlock()
 	info := r.manifestInfo
 	if tagged, ok := ref.(reference.Tagged); ok {
 		info.tag = tagged.Tag()
 		logrus.Tracef("distributionRepositoryWithManifestInfo: updated tag='%s' (from ref: %#v)", info.tag, ref)
 	}
 	if digested, ok := ref.(reference.Digested); ok {
-		info.digests = []string{digested.Digest().String()}
-		logrus.Tracef("distributionRepositoryWithManifestInfo: updated digests='%+v' (from ref: %#v)", info.digests, ref)
+		if err := info.chain.reset(digested.Digest()); err != nil {
+			r.mutex.Unlock()
+			return err
+		}
+		logrus.Tracef("distributionRepositoryWithManifestInfo: updated chain (from ref: %#v)", ref)
 	}
 	// Explicit digests have priority over the digest from the ref
 	if len(digests) > 0 {
-		info.digests = make([]string, 0, len(digests))
+		if err := info.chain.reset(""); err != nil {
+			r.mutex.Unlock()
+			return err
+		}
 		for _, dgst := range digests {
 			if dgst == "" {
 				continue
 			}
-			info.digests = append(info.digests, dgst.String())
+			parent, _ := info.chain.Leaf()
+			if err := info.chain.addChild(parent.Digest, distribution.Descriptor{Digest: dgst}); err != nil {
+				r.mutex.Unlock()
+				return err
+			}
 		}
-		logrus.Tracef("distributionRepositoryWithManifestInfo: updated digests='%+v'", info.digests)
+		logrus.Tracef("distributionRepositoryWithManifestInfo: updated chain='%+v'", info.chain.Entries())
 	}
 	r.manifestInfo = info
+	if leaf, ok := info.chain.Leaf(); ok && info.tag != "" {
+		r.recordTagDigest(info.tag, leaf.Digest)
+	}
+	r.mutex.Unlock()
+
+	leaf, _ := info.chain.Leaf()
+	r.emit(action, Target{Repository: r.repository, Tag: info.tag, Digest: leaf.Digest, MediaType: leaf.MediaType, Size: leaf.Size}, nil)
+	return nil
 }
 
-// addDigest adds a digest to the list of kept digests by this instance, as the last digest
-func (r *distributionRepositoryWithManifestInfo) addDigest(dgst digest.Digest) {
-	if dgst == "" {
-		return
+// addChild records child as resolved from parent in the chain kept by this instance, emitting an event tagged with
+// action (e.g. ActionPull for a pull, ActionPush for a push). If the chain is empty, parent is ignored and child
+// becomes the root. It returns an ErrInvalidDigest, leaving the chain unchanged, if child.Digest fails validation.
+func (r *distributionRepositoryWithManifestInfo) addChild(action Action, parent digest.Digest, child distribution.Descriptor) error {
+	if child.Digest == "" {
+		return nil
 	}
 	r.mutex.Lock()
-	defer r.mutex.Unlock()
-	r.manifestInfo.digests = append(r.manifestInfo.digests, dgst.String())
-	logrus.Tracef("distributionRepositoryWithManifestInfo: updated digests='%+v' (added: '%s')", r.manifestInfo.digests, dgst)
+	if err := r.manifestInfo.chain.addChild(parent, child); err != nil {
+		r.mutex.Unlock()
+		return err
+	}
+	tag := r.manifestInfo.tag
+	logrus.Tracef("distributionRepositoryWithManifestInfo: updated chain='%+v' (added child: '%s')", r.manifestInfo.chain.Entries(), child.Digest)
+	r.recordTagDigest(tag, child.Digest)
+	r.mutex.Unlock()
+
+	r.emit(action, Target{Repository: r.repository, Tag: tag, Digest: child.Digest, MediaType: child.MediaType, Size: child.Size}, nil)
+	return nil
+}
+
+// Chain returns a copy of the resolution chain recorded by this instance so far.
+func (r *distributionRepositoryWithManifestInfo) Chain() ResolutionChain {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return ResolutionChain{entries: r.manifestInfo.chain.Entries()}
 }
 
 // prepareRestoreInfo returns a function which can be used to restore the manifest info to the current state. This
@@ -102,11 +241,13 @@ func (r *distributionRepositoryWithManifestInfo) prepareRestoreInfo() func() {
 }
 
 // updateRepoWithManifestInfo safely calls distributionRepositoryWithManifestInfo.update if repo is a
-// distributionRepositoryWithManifestInfo
-func updateRepoWithManifestInfo(repo distribution.Repository, ref reference.Named, dgst ...digest.Digest) {
+// distributionRepositoryWithManifestInfo. action tags the event emitted for the update (e.g. ActionPull for a pull,
+// ActionPush for a push).
+func updateRepoWithManifestInfo(repo distribution.Repository, action Action, ref reference.Named, dgst ...digest.Digest) error {
 	if r, ok := repo.(*distributionRepositoryWithManifestInfo); ok {
-		r.update(ref, dgst...)
+		return r.update(action, ref, dgst...)
 	}
+	return nil
 }
 
 // prepareRestoreRepoWithManifestInfo safely calls distributionRepositoryWithManifestInfo.prepareRestoreInfo if repo is a
@@ -118,10 +259,30 @@ func prepareRestoreRepoWithManifestInfo(repo distribution.Repository) func() {
 	return func() {} // no-op
 }
 
-// addDigestToRepoWithManifestInfo safely calls distributionRepositoryWithManifestInfo.addDigest if repo is a
-// distributionRepositoryWithManifestInfo
-func addDigestToRepoWithManifestInfo(repo distribution.Repository, dgst digest.Digest) {
+// addChildToRepoWithManifestInfo safely calls distributionRepositoryWithManifestInfo.addChild if repo is a
+// distributionRepositoryWithManifestInfo. action tags the event emitted for the added child (e.g. ActionPull for a
+// pull, ActionPush for a push).
+func addChildToRepoWithManifestInfo(repo distribution.Repository, action Action, parent digest.Digest, child distribution.Descriptor) error {
+	if r, ok := repo.(*distributionRepositoryWithManifestInfo); ok {
+		return r.addChild(action, parent, child)
+	}
+	return nil
+}
+
+// chainForRepoWithManifestInfo safely calls distributionRepositoryWithManifestInfo.Chain if repo is a
+// distributionRepositoryWithManifestInfo, otherwise it returns an empty ResolutionChain.
+func chainForRepoWithManifestInfo(repo distribution.Repository) ResolutionChain {
+	if r, ok := repo.(*distributionRepositoryWithManifestInfo); ok {
+		return r.Chain()
+	}
+	return ResolutionChain{}
+}
+
+// addEventSinkToRepoWithManifestInfo safely calls distributionRepositoryWithManifestInfo.addEventSink if repo is a
+// distributionRepositoryWithManifestInfo, registering sink on a repo obtained from NewRepositoryWithManifestInfo
+// after construction. It is a no-op otherwise.
+func addEventSinkToRepoWithManifestInfo(repo distribution.Repository, sink EventSink) {
 	if r, ok := repo.(*distributionRepositoryWithManifestInfo); ok {
-		r.addDigest(dgst)
+		r.addEventSink(sink)
 	}
 }
@@ -1,10 +1,12 @@
 package distribution
 
 import (
+	"github.com/docker/distribution"
 	"github.com/docker/distribution/reference"
 	"github.com/opencontainers/go-digest"
 	"gotest.tools/v3/assert"
 	"gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/poll"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -28,11 +30,11 @@ func TestDistributionRepositoryWithManifestInfo_ModifyRequest(t *testing.T) {
 	refName, _ := reference.WithName("foo")
 	refWithTag1, _ := reference.WithTag(refName, "1.0")
 	refWithTag2, _ := reference.WithTag(refName, "2.0")
-	dgst1, _ := digest.Parse("sha256:12345678901234567890123456789012")
-	dgst2, _ := digest.Parse("sha256:23456789012345678901234567890123")
-	dgst3, _ := digest.Parse("sha256:34567890123456789012345678901234")
-	dgst4, _ := digest.Parse("sha256:45678901234567890123456789012345")
-	dgst5, _ := digest.Parse("sha256:56789012345678901234567890123456")
+	dgst1, _ := digest.Parse("sha256:514026875f3a6d9a679277216eb23421c1a7624b185c610c66b2c29f16715034")
+	dgst2, _ := digest.Parse("sha256:f27c60e95c2f542902749b2aaddf4bf3ab414db42ae44ea20c3a8e5d98457e91")
+	dgst3, _ := digest.Parse("sha256:113136111e5d53de61ad20e8c5c08948dbdd4e46de760baf2dd5871f1c75c707")
+	dgst4, _ := digest.Parse("sha256:7de4c1a490975c8d1f1aaaf25a3b29df44949162203a95d65c6524ab97bcf885")
+	dgst5, _ := digest.Parse("sha256:2d7f3ccba063fb3f60413b82d1b9c98a0fcd84b67cd822e5cbb650af32ecc434")
 	refWithDigest1, _ := reference.WithDigest(refName, dgst1)
 	refWithDigest3, _ := reference.WithDigest(refName, dgst3)
 
@@ -45,7 +47,7 @@ func TestDistributionRepositoryWithManifestInfo_ModifyRequest(t *testing.T) {
 
 	t.Run("update only ref tag", func(t *testing.T) {
 		req := newRequest()
-		repo.update(refWithTag1)
+		repo.update(ActionPull, refWithTag1)
 		err := repo.ModifyRequest(req)
 		assert.NilError(t, err)
 		assertExpectedHeaders(t, req, "1.0")
@@ -53,7 +55,7 @@ func TestDistributionRepositoryWithManifestInfo_ModifyRequest(t *testing.T) {
 
 	t.Run("update only digest from ref", func(t *testing.T) {
 		req := newRequest()
-		repo.update(refWithDigest1)
+		repo.update(ActionPull, refWithDigest1)
 		err := repo.ModifyRequest(req)
 		assert.NilError(t, err)
 		assertExpectedHeaders(t, req, "1.0", dgst1.String())
@@ -61,7 +63,7 @@ func TestDistributionRepositoryWithManifestInfo_ModifyRequest(t *testing.T) {
 
 	t.Run("update both ref tag and explicit digest", func(t *testing.T) {
 		req := newRequest()
-		repo.update(refWithTag2, dgst2)
+		repo.update(ActionPull, refWithTag2, dgst2)
 		err := repo.ModifyRequest(req)
 		assert.NilError(t, err)
 		assertExpectedHeaders(t, req, "2.0", dgst2.String())
@@ -69,7 +71,7 @@ func TestDistributionRepositoryWithManifestInfo_ModifyRequest(t *testing.T) {
 
 	t.Run("update with both ref digest and explicit digest", func(t *testing.T) {
 		req := newRequest()
-		repo.update(refWithDigest3, dgst4)
+		repo.update(ActionPull, refWithDigest3, dgst4)
 		err := repo.ModifyRequest(req)
 		assert.NilError(t, err)
 		assertExpectedHeaders(t, req, "2.0", dgst4.String())
@@ -77,9 +79,9 @@ func TestDistributionRepositoryWithManifestInfo_ModifyRequest(t *testing.T) {
 
 	restore := repo.prepareRestoreInfo()
 
-	t.Run("add digest", func(t *testing.T) {
+	t.Run("add child", func(t *testing.T) {
 		req := newRequest()
-		repo.addDigest(dgst5)
+		repo.addChild(ActionPull, dgst4, distribution.Descriptor{Digest: dgst5})
 		err := repo.ModifyRequest(req)
 		assert.NilError(t, err)
 		assertExpectedHeaders(t, req, "2.0", dgst4.String(), dgst5.String())
@@ -93,3 +95,56 @@ func TestDistributionRepositoryWithManifestInfo_ModifyRequest(t *testing.T) {
 		assertExpectedHeaders(t, req, "2.0", dgst4.String())
 	})
 }
+
+func waitForEvents(t *testing.T, sink *InMemoryEventSink, n int) []Event {
+	t.Helper()
+	poll.WaitOn(t, func(t poll.LogT) poll.Result {
+		if len(sink.Events()) >= n {
+			return poll.Success()
+		}
+		return poll.Continue("waiting for %d event(s), have %d", n, len(sink.Events()))
+	})
+	return sink.Events()
+}
+
+func TestDistributionRepositoryWithManifestInfo_Events(t *testing.T) {
+	dgst, _ := digest.Parse("sha256:514026875f3a6d9a679277216eb23421c1a7624b185c610c66b2c29f16715034")
+
+	t.Run("a sink added via NewRepositoryWithManifestInfo receives events emitted by update", func(t *testing.T) {
+		named, err := reference.WithName("library/alpine")
+		assert.NilError(t, err)
+		sink := NewInMemoryEventSink()
+
+		repo := NewRepositoryWithManifestInfo(&namedOnlyRepository{named: named}, nil, sink)
+		wrapper := repo.(*distributionRepositoryWithManifestInfo)
+		refWithDigest, err := reference.WithDigest(named, dgst)
+		assert.NilError(t, err)
+
+		assert.NilError(t, wrapper.update(ActionPush, refWithDigest))
+
+		events := waitForEvents(t, sink, 1)
+		assert.Equal(t, events[0].Action, ActionPush)
+		assert.Equal(t, events[0].Target.Digest, dgst)
+		assert.Equal(t, events[0].Target.Repository, "library/alpine")
+	})
+
+	t.Run("a sink added via addEventSinkToRepoWithManifestInfo receives events emitted by ModifyRequest", func(t *testing.T) {
+		named, err := reference.WithName("library/alpine")
+		assert.NilError(t, err)
+
+		repo := NewRepositoryWithManifestInfo(&namedOnlyRepository{named: named}, nil)
+		sink := NewInMemoryEventSink()
+		addEventSinkToRepoWithManifestInfo(repo, sink)
+
+		req := httptest.NewRequest(http.MethodGet, "https://www.example.com/v2/library/alpine/manifests/latest", nil)
+		assert.NilError(t, repo.(*distributionRepositoryWithManifestInfo).ModifyRequest(req))
+
+		events := waitForEvents(t, sink, 1)
+		assert.Equal(t, events[0].Action, ActionPull)
+		assert.Equal(t, events[0].Target.Tag, "latest")
+	})
+
+	t.Run("addEventSinkToRepoWithManifestInfo is a no-op for a foreign Repository", func(t *testing.T) {
+		addEventSinkToRepoWithManifestInfo(&namedOnlyRepository{}, NewInMemoryEventSink())
+	})
+}
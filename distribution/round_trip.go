@@ -0,0 +1,81 @@
+package distribution
+
+import (
+	"net/http"
+
+	"github.com/docker/distribution"
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+)
+
+var _ http.RoundTripper = (*distributionRepositoryWithManifestInfo)(nil)
+
+// RoundTrip sends req via the configured base transport (http.DefaultTransport if none was set), after applying
+// ModifyRequest, then cross-checks the response's Docker-Content-Digest header against the leaf digest tracked for
+// this request. A mismatch is recorded rather than returned, since the daemon trusting a wrong digest is a
+// consistency problem for the caller to surface through LastResolutionError, not a transport failure.
+func (r *distributionRepositoryWithManifestInfo) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := r.ModifyRequest(req); err != nil {
+		return nil, err
+	}
+	base := r.transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	action, ok := actionForRequest(req.Method)
+	if !ok {
+		action = ActionPull
+	}
+	r.checkContentDigest(action, resp)
+	return resp, nil
+}
+
+// checkContentDigest reads the Docker-Content-Digest header off resp and compares it against the tracked leaf
+// digest, recording the outcome for LastResolutionError. If no leaf digest is tracked yet, the served digest is
+// recorded as the new leaf instead of being treated as a mismatch, tagging the event emitted for it with action.
+func (r *distributionRepositoryWithManifestInfo) checkContentDigest(action Action, resp *http.Response) {
+	header := resp.Header.Get("Docker-Content-Digest")
+	if header == "" {
+		return
+	}
+	served, err := digest.Parse(header)
+	if err != nil {
+		r.setLastResolutionError(ErrInvalidDigest{Digest: digest.Digest(header), Err: err})
+		return
+	}
+
+	r.mutex.RLock()
+	leaf, ok := r.manifestInfo.chain.Leaf()
+	r.mutex.RUnlock()
+
+	if !ok {
+		r.setLastResolutionError(r.addChild(action, "", distribution.Descriptor{Digest: served}))
+		return
+	}
+	if leaf.Digest != served {
+		r.setLastResolutionError(ErrDigestMismatch{Expected: leaf.Digest, Actual: served})
+		return
+	}
+	r.setLastResolutionError(nil)
+}
+
+func (r *distributionRepositoryWithManifestInfo) setLastResolutionError(err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.lastResolutionErr = err
+	if err != nil {
+		logrus.WithError(err).Warn("distributionRepositoryWithManifestInfo: Docker-Content-Digest cross-check failed")
+	}
+}
+
+// LastResolutionError returns the outcome of the most recent Docker-Content-Digest cross-check performed by
+// RoundTrip: nil if the registry-served digest matched the tracked leaf digest, or nothing has been checked yet.
+func (r *distributionRepositoryWithManifestInfo) LastResolutionError() error {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.lastResolutionErr
+}
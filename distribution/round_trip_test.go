@@ -0,0 +1,72 @@
+package distribution
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docker/distribution"
+	"github.com/opencontainers/go-digest"
+	"gotest.tools/v3/assert"
+)
+
+type stubRoundTripper struct {
+	header string
+}
+
+func (s stubRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp := httptest.NewRecorder()
+	if s.header != "" {
+		resp.Header().Set("Docker-Content-Digest", s.header)
+	}
+	resp.WriteHeader(http.StatusOK)
+	return resp.Result(), nil
+}
+
+func TestDistributionRepositoryWithManifestInfo_RoundTrip(t *testing.T) {
+	dgst, _ := digest.Parse("sha256:514026875f3a6d9a679277216eb23421c1a7624b185c610c66b2c29f16715034")
+	otherDgst, _ := digest.Parse("sha256:f27c60e95c2f542902749b2aaddf4bf3ab414db42ae44ea20c3a8e5d98457e91")
+	newRequest := func() *http.Request { return httptest.NewRequest(http.MethodGet, "https://www.example.com", nil) }
+
+	t.Run("no digest header leaves LastResolutionError nil", func(t *testing.T) {
+		repo := &distributionRepositoryWithManifestInfo{transport: stubRoundTripper{}}
+		_, err := repo.RoundTrip(newRequest())
+		assert.NilError(t, err)
+		assert.NilError(t, repo.LastResolutionError())
+	})
+
+	t.Run("matching digest header leaves LastResolutionError nil", func(t *testing.T) {
+		repo := &distributionRepositoryWithManifestInfo{transport: stubRoundTripper{header: dgst.String()}}
+		assert.NilError(t, repo.addChild(ActionPull, "", distribution.Descriptor{Digest: dgst}))
+		_, err := repo.RoundTrip(newRequest())
+		assert.NilError(t, err)
+		assert.NilError(t, repo.LastResolutionError())
+	})
+
+	t.Run("mismatched digest header is recorded", func(t *testing.T) {
+		repo := &distributionRepositoryWithManifestInfo{transport: stubRoundTripper{header: otherDgst.String()}}
+		assert.NilError(t, repo.addChild(ActionPull, "", distribution.Descriptor{Digest: dgst}))
+		_, err := repo.RoundTrip(newRequest())
+		assert.NilError(t, err)
+		assert.Error(t, repo.LastResolutionError(), ErrDigestMismatch{Expected: dgst, Actual: otherDgst}.Error())
+	})
+
+	t.Run("unset leaf fills in from the served digest", func(t *testing.T) {
+		repo := &distributionRepositoryWithManifestInfo{transport: stubRoundTripper{header: dgst.String()}}
+		_, err := repo.RoundTrip(newRequest())
+		assert.NilError(t, err)
+		assert.NilError(t, repo.LastResolutionError())
+		leaf, ok := repo.Chain().Leaf()
+		assert.Check(t, ok)
+		assert.Equal(t, leaf.Digest, dgst)
+	})
+}
+
+func TestDistributionRepositoryWithManifestInfo_AddChild_InvalidDigest(t *testing.T) {
+	repo := &distributionRepositoryWithManifestInfo{}
+	err := repo.addChild(ActionPull, "", distribution.Descriptor{Digest: digest.Digest("not-a-digest")})
+	assert.Check(t, err != nil)
+	var invalid ErrInvalidDigest
+	assert.Check(t, errors.As(err, &invalid))
+}
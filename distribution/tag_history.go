@@ -0,0 +1,96 @@
+package distribution
+
+import (
+	"context"
+
+	"github.com/docker/distribution"
+	"github.com/opencontainers/go-digest"
+)
+
+// defaultTagHistoryCap is the number of distinct tags a distributionRepositoryWithManifestInfo keeps digest history
+// for before evicting the least recently inserted one, used when tagHistoryCap is left at its zero value.
+const defaultTagHistoryCap = 100
+
+// recordTagDigest appends dgst to the history kept for tag, evicting the oldest tracked tag if doing so would grow
+// the history past its cap. The caller must hold r.mutex for writing.
+func (r *distributionRepositoryWithManifestInfo) recordTagDigest(tag string, dgst digest.Digest) {
+	if tag == "" || dgst == "" {
+		return
+	}
+	if r.tagDigests == nil {
+		r.tagDigests = make(map[string][]digest.Digest)
+	}
+	history, tracked := r.tagDigests[tag]
+	if !tracked {
+		r.tagHistoryOrder = append(r.tagHistoryOrder, tag)
+		if limit := r.maxTagHistory(); len(r.tagHistoryOrder) > limit {
+			evict := r.tagHistoryOrder[0]
+			r.tagHistoryOrder = r.tagHistoryOrder[1:]
+			delete(r.tagDigests, evict)
+		}
+	}
+	if n := len(history); n == 0 || history[n-1] != dgst {
+		r.tagDigests[tag] = append(history, dgst)
+	}
+}
+
+// maxTagHistory returns the configured tag history cap, or defaultTagHistoryCap if none was configured.
+func (r *distributionRepositoryWithManifestInfo) maxTagHistory() int {
+	if r.tagHistoryCap > 0 {
+		return r.tagHistoryCap
+	}
+	return defaultTagHistoryCap
+}
+
+// ManifestDigests returns every digest observed for tag during this instance's lifetime, oldest first. It returns
+// distribution.ErrTagUnknown if tag has never been observed, or if its history has since been evicted.
+func (r *distributionRepositoryWithManifestInfo) ManifestDigests(ctx context.Context, tag string) ([]digest.Digest, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	history, ok := r.tagDigests[tag]
+	if !ok {
+		return nil, distribution.ErrTagUnknown{Tag: tag}
+	}
+	digests := make([]digest.Digest, len(history))
+	copy(digests, history)
+	return digests, nil
+}
+
+// TagsForDigest returns every tag whose history includes dgst, in the order they were first observed. It returns an
+// empty slice, not an error, if no tag's history includes dgst.
+//
+// Named TagsForDigest, not Tags, because distribution.Repository already declares a Tags(ctx) TagService method and
+// this wrapper embeds distribution.Repository - reusing the name would shadow the embedded method and break the
+// wrapper's Repository conformance.
+func (r *distributionRepositoryWithManifestInfo) TagsForDigest(ctx context.Context, dgst digest.Digest) ([]string, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	var tags []string
+	for _, tag := range r.tagHistoryOrder {
+		for _, d := range r.tagDigests[tag] {
+			if d == dgst {
+				tags = append(tags, tag)
+				break
+			}
+		}
+	}
+	return tags, nil
+}
+
+// manifestDigestsForRepoWithManifestInfo safely calls distributionRepositoryWithManifestInfo.ManifestDigests if repo
+// is a distributionRepositoryWithManifestInfo, otherwise it returns an empty result.
+func manifestDigestsForRepoWithManifestInfo(ctx context.Context, repo distribution.Repository, tag string) ([]digest.Digest, error) {
+	if r, ok := repo.(*distributionRepositoryWithManifestInfo); ok {
+		return r.ManifestDigests(ctx, tag)
+	}
+	return nil, nil
+}
+
+// tagsForDigestOnRepoWithManifestInfo safely calls distributionRepositoryWithManifestInfo.TagsForDigest if repo is a
+// distributionRepositoryWithManifestInfo, otherwise it returns an empty result.
+func tagsForDigestOnRepoWithManifestInfo(ctx context.Context, repo distribution.Repository, dgst digest.Digest) ([]string, error) {
+	if r, ok := repo.(*distributionRepositoryWithManifestInfo); ok {
+		return r.TagsForDigest(ctx, dgst)
+	}
+	return nil, nil
+}
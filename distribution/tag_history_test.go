@@ -0,0 +1,75 @@
+package distribution
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/distribution"
+	"github.com/opencontainers/go-digest"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+)
+
+func TestDistributionRepositoryWithManifestInfo_TagHistory(t *testing.T) {
+	ctx := context.Background()
+	dgst1, _ := digest.Parse("sha256:514026875f3a6d9a679277216eb23421c1a7624b185c610c66b2c29f16715034")
+	dgst2, _ := digest.Parse("sha256:f27c60e95c2f542902749b2aaddf4bf3ab414db42ae44ea20c3a8e5d98457e91")
+	dgst3, _ := digest.Parse("sha256:113136111e5d53de61ad20e8c5c08948dbdd4e46de760baf2dd5871f1c75c707")
+
+	t.Run("unknown tag", func(t *testing.T) {
+		repo := &distributionRepositoryWithManifestInfo{}
+		digests, err := repo.ManifestDigests(ctx, "latest")
+		assert.Check(t, cmp.Nil(digests))
+		assert.Check(t, cmp.ErrorType(err, distribution.ErrTagUnknown{}))
+	})
+
+	t.Run("accumulates digests instead of overwriting", func(t *testing.T) {
+		repo := &distributionRepositoryWithManifestInfo{}
+		repo.recordTagDigest("latest", dgst1)
+		repo.recordTagDigest("latest", dgst2)
+		repo.recordTagDigest("latest", dgst1)
+
+		digests, err := repo.ManifestDigests(ctx, "latest")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(digests, []digest.Digest{dgst1, dgst2, dgst1}))
+	})
+
+	t.Run("ignores consecutive duplicate digests", func(t *testing.T) {
+		repo := &distributionRepositoryWithManifestInfo{}
+		repo.recordTagDigest("latest", dgst1)
+		repo.recordTagDigest("latest", dgst1)
+
+		digests, err := repo.ManifestDigests(ctx, "latest")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(digests, []digest.Digest{dgst1}))
+	})
+
+	t.Run("TagsForDigest finds every tag which ever pointed at a digest", func(t *testing.T) {
+		repo := &distributionRepositoryWithManifestInfo{}
+		repo.recordTagDigest("1.0", dgst1)
+		repo.recordTagDigest("latest", dgst1)
+		repo.recordTagDigest("latest", dgst2)
+
+		tags, err := repo.TagsForDigest(ctx, dgst1)
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(tags, []string{"1.0", "latest"}))
+
+		tags, err = repo.TagsForDigest(ctx, dgst3)
+		assert.NilError(t, err)
+		assert.Check(t, len(tags) == 0)
+	})
+
+	t.Run("evicts the oldest tag once the cap is exceeded", func(t *testing.T) {
+		repo := &distributionRepositoryWithManifestInfo{tagHistoryCap: 2}
+		repo.recordTagDigest("1.0", dgst1)
+		repo.recordTagDigest("2.0", dgst2)
+		repo.recordTagDigest("3.0", dgst3)
+
+		_, err := repo.ManifestDigests(ctx, "1.0")
+		assert.Check(t, cmp.ErrorType(err, distribution.ErrTagUnknown{}))
+
+		digests, err := repo.ManifestDigests(ctx, "3.0")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(digests, []digest.Digest{dgst3}))
+	})
+}